@@ -18,13 +18,22 @@ package fetch
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/stockparfait/errors"
+	"golang.org/x/time/rate"
 )
 
 type clientKeyType string
@@ -60,12 +69,323 @@ func NewRetriableError(e error) *RetriableError {
 	return &RetriableError{e}
 }
 
+// RetryAfterError is a RetriableError carrying a server-requested delay, as
+// parsed from a Retry-After response header. Retry uses Delay as a lower
+// bound for the next sleep, capped at Params.RetryMaxWait.
+type RetryAfterError struct {
+	*RetriableError
+	Delay time.Duration
+}
+
+// NewRetryAfterError makes a RetriableError that additionally requests a
+// minimum delay before the next attempt.
+func NewRetryAfterError(e error, delay time.Duration) *RetryAfterError {
+	return &RetryAfterError{NewRetriableError(e), delay}
+}
+
+// Unwrap exposes the wrapped RetriableError, so errors.As / errors.Is can
+// match either RetryAfterError or RetriableError.
+func (e *RetryAfterError) Unwrap() error {
+	return e.RetriableError
+}
+
+// RetryPolicy classifies the outcome of a single Get attempt: resp is the
+// response received, or nil on a transport failure; err is the corresponding
+// transport error, or nil on a completed (possibly non-2xx) response. It
+// returns whether the attempt should be retried and, for a transport
+// failure, the error to surface to the caller (err itself if outErr is nil),
+// which Get wraps in RetriableError when retriable is true. For a completed
+// response only retriable is used, and only for a non-2xx status: Get builds
+// the error from the status code, and outErr is ignored.
+type RetryPolicy func(resp *http.Response, err error) (retriable bool, outErr error)
+
+// DefaultRetryPolicy retries 5xx, 429 and 408 responses, and common
+// transient transport failures (timeouts, connection resets, unexpected
+// EOF), but never certificate validation failures: retrying a bad cert just
+// wastes time.
+func DefaultRetryPolicy(resp *http.Response, err error) (bool, error) {
+	if err != nil {
+		return isTransportRetriable(err), err
+	}
+	return ResponseRetriable(resp), nil
+}
+
+// isTransportRetriable classifies a transport-level error (as opposed to an
+// HTTP status code) as transient.
+func isTransportRetriable(err error) bool {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		err = uerr.Err
+	}
+	var certErr x509.UnknownAuthorityError
+	if errors.As(err, &certErr) {
+		return false
+	}
+	var hostErr x509.HostnameError
+	if errors.As(err, &hostErr) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout() || netErr.Temporary() //nolint:staticcheck // Temporary is deprecated but still the best signal available.
+	}
+	return false
+}
+
+// Limiter paces a sequence of operations (e.g. outbound HTTP requests): Wait
+// blocks until the next one is permitted, or ctx is canceled.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// NewRateLimiter returns a token-bucket Limiter allowing rps operations per
+// second on average, with bursts up to burst.
+func NewRateLimiter(rps float64, burst int) Limiter {
+	return rate.NewLimiter(rate.Limit(rps), burst)
+}
+
+// HostLimiter applies a separate Limiter per request URL's "host:port", so
+// hammering one endpoint doesn't eat into another's budget. Limiters are
+// created lazily, the first time each host is seen, via newLimiter.
+type HostLimiter struct {
+	newLimiter func() Limiter
+
+	mu       sync.Mutex
+	limiters map[string]Limiter
+}
+
+// NewHostLimiter creates a HostLimiter that builds a fresh per-host Limiter
+// with newLimiter, e.g.:
+//
+//	NewHostLimiter(func() Limiter { return NewRateLimiter(5, 10) })
+func NewHostLimiter(newLimiter func() Limiter) *HostLimiter {
+	return &HostLimiter{newLimiter: newLimiter, limiters: make(map[string]Limiter)}
+}
+
+// Wait blocks until a request to uri's host is permitted, or ctx is
+// canceled.
+func (h *HostLimiter) Wait(ctx context.Context, uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return errors.Annotate(err, "failed to parse URL for rate limiting")
+	}
+	h.mu.Lock()
+	l, ok := h.limiters[u.Host]
+	if !ok {
+		l = h.newLimiter()
+		h.limiters[u.Host] = l
+	}
+	h.mu.Unlock()
+	return l.Wait(ctx)
+}
+
+// semaphore bounds concurrency; a nil semaphore imposes no limit.
+type semaphore chan struct{}
+
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s semaphore) release() {
+	if s == nil {
+		return
+	}
+	<-s
+}
+
+// BreakerState is the state of a Breaker for a given key.
+type BreakerState int
+
+// The states of a circuit breaker.
+const (
+	BreakerClosed   BreakerState = iota // calls pass through normally
+	BreakerOpen                         // calls fail fast with CircuitOpenError
+	BreakerHalfOpen                     // a single probe call is allowed through
+)
+
+// CircuitOpenError is returned by Breaker.Allow, and surfaces from
+// GetRetry, Do and FetchJSONStream, when a circuit is open and the call is
+// failed fast instead of being attempted. Get itself does not consult a
+// Breaker.
+type CircuitOpenError struct {
+	Key   string
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %q is open until %s", e.Key, e.Until.Format(time.RFC3339))
+}
+
+// breakerEntry tracks the circuit state for a single key.
+type breakerEntry struct {
+	state          BreakerState
+	consecutiveErr int
+	windowStart    time.Time
+	openUntil      time.Time
+	probing        bool // a half-open probe is currently in flight
+}
+
+// Breaker is a per-key circuit breaker. After FailureThreshold consecutive
+// failures within Window, the circuit for that key opens and fails fast for
+// CooldownDuration; it then moves to half-open and allows a single probe
+// call through, closing on success or re-opening on failure.
+type Breaker struct {
+	FailureThreshold int           // consecutive failures within Window that trip the breaker
+	Window           time.Duration // a failure later than this after the run's first failure restarts the count; not a sliding window
+	CooldownDuration time.Duration // how long an open circuit fails fast
+	OnOpen           func(key string)
+	OnClose          func(key string)
+	OnReject         func(key string)
+
+	mu      sync.Mutex
+	entries map[string]*breakerEntry
+}
+
+// NewBreaker creates a Breaker tripping after failureThreshold consecutive
+// failures within window, staying open for cooldown before probing again.
+func NewBreaker(failureThreshold int, window, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		FailureThreshold: failureThreshold,
+		Window:           window,
+		CooldownDuration: cooldown,
+		entries:          make(map[string]*breakerEntry),
+	}
+}
+
+// Allow checks whether a call keyed by key may proceed. A non-nil error is
+// always a *CircuitOpenError; the caller must not attempt the call. On nil
+// error, the caller must invoke the returned function exactly once with the
+// outcome of the call, to update the circuit's state.
+func (b *Breaker) Allow(key string) (func(success bool), error) {
+	b.mu.Lock()
+	e, ok := b.entries[key]
+	if !ok {
+		e = &breakerEntry{}
+		b.entries[key] = e
+	}
+	now := time.Now()
+	switch e.state {
+	case BreakerOpen:
+		if now.Before(e.openUntil) {
+			until := e.openUntil
+			b.mu.Unlock()
+			if b.OnReject != nil {
+				b.OnReject(key)
+			}
+			return nil, &CircuitOpenError{Key: key, Until: until}
+		}
+		e.state = BreakerHalfOpen
+		e.probing = true
+	case BreakerHalfOpen:
+		if e.probing {
+			until := e.openUntil
+			b.mu.Unlock()
+			if b.OnReject != nil {
+				b.OnReject(key)
+			}
+			return nil, &CircuitOpenError{Key: key, Until: until}
+		}
+		e.probing = true
+	}
+	b.mu.Unlock()
+
+	return func(success bool) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		e := b.entries[key]
+		if e == nil {
+			return
+		}
+		if e.state == BreakerHalfOpen {
+			e.probing = false
+			if success {
+				e.state = BreakerClosed
+				e.consecutiveErr = 0
+				e.windowStart = time.Time{}
+				if b.OnClose != nil {
+					b.OnClose(key)
+				}
+			} else {
+				e.state = BreakerOpen
+				e.openUntil = time.Now().Add(b.CooldownDuration)
+				if b.OnOpen != nil {
+					b.OnOpen(key)
+				}
+			}
+			return
+		}
+		if success {
+			e.consecutiveErr = 0
+			e.windowStart = time.Time{}
+			return
+		}
+		now := time.Now()
+		if e.windowStart.IsZero() || now.Sub(e.windowStart) > b.Window {
+			e.windowStart = now
+			e.consecutiveErr = 1
+		} else {
+			e.consecutiveErr++
+		}
+		if e.consecutiveErr >= b.FailureThreshold {
+			e.state = BreakerOpen
+			e.openUntil = now.Add(b.CooldownDuration)
+			if b.OnOpen != nil {
+				b.OnOpen(key)
+			}
+		}
+	}, nil
+}
+
+// breakerKey derives the Breaker key for uri: params.BreakerKeyFunc if set,
+// otherwise the URL's host.
+func breakerKey(params *Params, uri string) string {
+	if params.BreakerKeyFunc != nil {
+		return params.BreakerKeyFunc(uri)
+	}
+	if u, err := url.Parse(uri); err == nil {
+		return u.Host
+	}
+	return uri
+}
+
 // Params defines the retry policy.
 type Params struct {
-	NumRetries   int
-	RetryMinWait time.Duration    // time to wait before the first retry
-	RetryMaxWait time.Duration    // exponential backoff caps at this value
-	IsRetriable  func(error) bool // only retry when fn(err) == true
+	NumRetries     int
+	RetryMinWait   time.Duration       // time to wait before the first retry
+	RetryMaxWait   time.Duration       // exponential backoff caps at this value
+	Jitter         float64             // randomize wait by +/- this fraction, in [0, 1]
+	IsRetriable    func(error) bool    // only retry when fn(err) == true
+	RetryPolicy    RetryPolicy         // classifies a Get attempt as retriable
+	MaxBodySize    int64               // cap on buffering an unseekable request body; see NewRequest
+	Limiter        Limiter             // paced before each Retry attempt, if set
+	HostLimiter    *HostLimiter        // paced before each GetRetry/Do/FetchJSONStream attempt, keyed by URL host
+	MaxInFlight    int                 // caps concurrent in-flight Retry calls sharing this *Params
+	Breaker        *Breaker            // consulted before each GetRetry/Do/FetchJSONStream attempt, keyed by breakerKey
+	BreakerKeyFunc func(string) string // derives the Breaker key from a URL; defaults to its host
+
+	semOnce sync.Once
+	sem     semaphore
 }
 
 // NewParams creates the default value of Params.
@@ -74,13 +394,24 @@ func NewParams() *Params {
 		NumRetries:   3, // number of retries; when 0, `fn` is called only once
 		RetryMinWait: time.Second,
 		RetryMaxWait: time.Minute,
+		Jitter:       0,
 		IsRetriable: func(e error) bool {
-			_, ok := e.(*RetriableError)
-			return ok
+			var re *RetriableError
+			return errors.As(e, &re)
 		},
+		RetryPolicy: DefaultRetryPolicy,
 	}
 }
 
+// semaphoreFor lazily builds the MaxInFlight semaphore on first use, shared
+// by every Retry call against this *Params.
+func (p *Params) semaphoreFor() semaphore {
+	p.semOnce.Do(func() {
+		p.sem = newSemaphore(p.MaxInFlight)
+	})
+	return p.sem
+}
+
 // Retries sets `NumRetries` parameter.
 func (p *Params) Retries(r int) *Params {
 	p.NumRetries = r
@@ -99,22 +430,103 @@ func (p *Params) MaxWait(d time.Duration) *Params {
 	return p
 }
 
+// JitterFraction sets `Jitter` parameter.
+func (p *Params) JitterFraction(j float64) *Params {
+	p.Jitter = j
+	return p
+}
+
 // IsRetriableFn sets `IsRetriable` parameter.
 func (p *Params) IsRetriableFn(f func(e error) bool) *Params {
 	p.IsRetriable = f
 	return p
 }
 
+// WithRetryPolicy sets `RetryPolicy` parameter.
+func (p *Params) WithRetryPolicy(rp RetryPolicy) *Params {
+	p.RetryPolicy = rp
+	return p
+}
+
+// WithMaxBodySize sets `MaxBodySize` parameter.
+func (p *Params) WithMaxBodySize(n int64) *Params {
+	p.MaxBodySize = n
+	return p
+}
+
+// WithLimiter sets `Limiter` parameter.
+func (p *Params) WithLimiter(l Limiter) *Params {
+	p.Limiter = l
+	return p
+}
+
+// WithHostLimiter sets `HostLimiter` parameter.
+func (p *Params) WithHostLimiter(hl *HostLimiter) *Params {
+	p.HostLimiter = hl
+	return p
+}
+
+// WithMaxInFlight sets `MaxInFlight` parameter. It must be called before the
+// first use of Params with Retry; changing it afterwards has no effect, as
+// the semaphore is sized lazily on first use.
+func (p *Params) WithMaxInFlight(n int) *Params {
+	p.MaxInFlight = n
+	return p
+}
+
+// WithBreaker sets `Breaker` parameter.
+func (p *Params) WithBreaker(b *Breaker) *Params {
+	p.Breaker = b
+	return p
+}
+
+// WithBreakerKeyFunc sets `BreakerKeyFunc` parameter.
+func (p *Params) WithBreakerKeyFunc(f func(string) string) *Params {
+	p.BreakerKeyFunc = f
+	return p
+}
+
 // Retriable is a callback function which, if fails with a RetriableError, can
 // be retried.
 type Retriable func(attempt int) error
 
+// jitter randomizes d by +/- fraction j, clamping j to [0, 1].
+func jitter(d time.Duration, j float64) time.Duration {
+	if j <= 0 {
+		return d
+	}
+	if j > 1 {
+		j = 1
+	}
+	lo := float64(d) * (1 - j)
+	hi := float64(d) * (1 + j)
+	return time.Duration(lo + rand.Float64()*(hi-lo))
+}
+
+// sleep blocks for d, or until ctx is canceled, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Retry calls `fn` and retries it if it returns a retriable error, and returns
 // the last error from `fn`, or from the context if it closes. Retry blocks
 // until all the retries finish. This method is context-aware; it will stop
 // retrying when the context is canceled. In particular, if it is called with a
 // canceled context, it will not run `fn` at all.
 func Retry(ctx context.Context, params *Params, fn Retriable) error {
+	sem := params.semaphoreFor()
+	if err := sem.acquire(ctx); err != nil {
+		return errors.Annotate(err, "context is canceled while waiting for an in-flight slot")
+	}
+	defer sem.release()
+
 	wait := params.RetryMinWait
 	var err error
 	for i := 0; i <= params.NumRetries; i++ {
@@ -123,6 +535,11 @@ func Retry(ctx context.Context, params *Params, fn Retriable) error {
 			return errors.Annotate(ctx.Err(), "context is canceled")
 		default:
 		}
+		if params.Limiter != nil {
+			if err := params.Limiter.Wait(ctx); err != nil {
+				return errors.Annotate(err, "context is canceled while rate-limited")
+			}
+		}
 		err = fn(i)
 		if err == nil {
 			return nil
@@ -130,7 +547,17 @@ func Retry(ctx context.Context, params *Params, fn Retriable) error {
 		if !params.IsRetriable(err) {
 			return errors.Annotate(err, "error cannot be retried")
 		}
-		time.Sleep(wait)
+		thisWait := jitter(wait, params.Jitter)
+		var ra *RetryAfterError
+		if errors.As(err, &ra) && ra.Delay > thisWait {
+			thisWait = ra.Delay
+		}
+		if thisWait > params.RetryMaxWait {
+			thisWait = params.RetryMaxWait
+		}
+		if waitErr := sleep(ctx, thisWait); waitErr != nil {
+			return errors.Annotate(waitErr, "context is canceled")
+		}
 		wait = 2 * wait
 		if wait > params.RetryMaxWait {
 			wait = params.RetryMaxWait
@@ -144,17 +571,82 @@ func ResponseOK(r *http.Response) bool {
 	return 200 <= r.StatusCode && r.StatusCode <= 299
 }
 
-// ResponseRetriable checks if an unsuccessful response can be
-// retried. Normally, these are 5xx codes.
+// ResponseRetriable checks if an unsuccessful response can be retried.
+// These are 5xx codes, as well as 429 (Too Many Requests) and 408 (Request
+// Timeout).
 func ResponseRetriable(r *http.Response) bool {
+	switch r.StatusCode {
+	case http.StatusTooManyRequests, http.StatusRequestTimeout:
+		return true
+	}
 	return 500 <= r.StatusCode && r.StatusCode <= 599
 }
 
-// Get sends a "GET" request using the `uri` with optional query parameters. It
-// returns a non-nil error if the request completes with a code outside of 2xx.
-// For retriable status codes (5xx) the error is RetriableError, making it
-// compatible with `Retry()`.
-func Get(ctx context.Context, uri string, query url.Values) (*http.Response, error) {
+// parseRetryAfter parses a Retry-After header value, in either the
+// delta-seconds or HTTP-date form (see RFC 7231 7.1.3), into a duration from
+// now. Returns false if the header is absent or malformed.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// classifyAttempt turns the outcome of one HTTP round-trip (resp is nil on a
+// transport failure) into: nil on success, RetriableError / RetryAfterError
+// on a transient failure per params.RetryPolicy, or a plain error otherwise.
+func classifyAttempt(verb, uri string, resp *http.Response, getErr error, params *Params) error {
+	retriable, err := params.RetryPolicy(resp, getErr)
+	if getErr != nil {
+		if err == nil {
+			err = getErr
+		}
+		wrapped := errors.Annotate(err, "failed to %s URL", verb)
+		if retriable {
+			return NewRetriableError(wrapped)
+		}
+		return wrapped
+	}
+	if ResponseOK(resp) {
+		return nil
+	}
+	if retriable {
+		retriableErr := errors.Reason("url: %s, response code %s", uri, resp.Status)
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return NewRetryAfterError(retriableErr, delay)
+		}
+		return NewRetriableError(retriableErr)
+	}
+	// The body of the response may have additional info, add it to the error.
+	body := bytes.NewBuffer(nil)
+	body.ReadFrom(resp.Body)
+	return errors.Reason(
+		"url: %s, response code %s, body: %s", uri, resp.Status, body.String())
+}
+
+// Get sends a "GET" request using the `uri` with optional query parameters.
+// It returns a non-nil error if the request fails to complete, or completes
+// with a code outside of 2xx. When `params.RetryPolicy` (DefaultRetryPolicy
+// if `params` is nil) classifies the failure as transient, the error is
+// RetriableError, making it compatible with `Retry()`. If the response also
+// carries a Retry-After header, the error is a *RetryAfterError instead, so
+// Retry() can honor the server's requested delay.
+func Get(ctx context.Context, uri string, query url.Values, params *Params) (*http.Response, error) {
+	if params == nil {
+		params = NewParams()
+	}
 	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, errors.Annotate(err, "failed to create HTTP request")
@@ -167,35 +659,238 @@ func Get(ctx context.Context, uri string, query url.Values) (*http.Response, err
 	if query != nil {
 		req.URL.RawQuery = query.Encode()
 	}
-	resp, err := client.Get(req.URL.String())
-	if err != nil {
-		return resp, errors.Annotate(err, "failed to GET URL")
-	}
-	if ResponseOK(resp) {
-		return resp, nil
-	}
-	if ResponseRetriable(resp) {
-		return resp, NewRetriableError(err)
+	resp, getErr := client.Do(req)
+	if err := classifyAttempt("GET", uri, resp, getErr, params); err != nil {
+		return resp, err
 	}
-	// The body of the response may have additional info, add it to the error.
-	body := bytes.NewBuffer(nil)
-	body.ReadFrom(resp.Body)
-	return resp, errors.Reason(
-		"url: %s, response code %s, body: %s", uri, resp.Status, body.String())
+	return resp, nil
 }
 
-// GetRetry is like Get that retries transient failures.
+// GetRetry is like Get that retries transient failures. Like Do, it drains
+// and closes each failed attempt's response body before retrying, so its
+// connection is freed back to the pool.
 func GetRetry(ctx context.Context, uri string, query url.Values, params *Params) (resp *http.Response, err error) {
 	if params == nil {
 		params = NewParams()
 	}
 	err = Retry(ctx, params, func(i int) (err error) {
-		resp, err = Get(ctx, uri, query)
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body) //nolint:errcheck // best-effort drain before retry
+			resp.Body.Close()
+			resp = nil
+		}
+		report, err := gate(ctx, params, uri)
+		if err != nil {
+			return err
+		}
+		resp, err = Get(ctx, uri, query, params)
+		if report != nil {
+			report(err == nil)
+		}
 		return
 	})
 	return
 }
 
+// gate applies params.HostLimiter and params.Breaker, in that order, before
+// an attempt against uri: it blocks until HostLimiter admits the request,
+// then consults Breaker. On success, it returns the report func to call with
+// the attempt's outcome (nil if params.Breaker is nil); on failure, the
+// attempt must not proceed and report is nil.
+func gate(ctx context.Context, params *Params, uri string) (report func(bool), err error) {
+	if params.HostLimiter != nil {
+		if err := params.HostLimiter.Wait(ctx, uri); err != nil {
+			return nil, errors.Annotate(err, "context is canceled while rate-limited")
+		}
+	}
+	if params.Breaker != nil {
+		if report, err = params.Breaker.Allow(breakerKey(params, uri)); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// DefaultMaxBodySize is the default cap, in bytes, on how much of an
+// unseekable request body NewRequest will buffer in memory to make it
+// replayable. See Params.MaxBodySize.
+const DefaultMaxBodySize int64 = 1 << 20 // 1 MiB
+
+// newBodyGetter normalizes the loose body types accepted by NewRequest into
+// an http.Request.GetBody-compatible factory, plus the body's length (-1 if
+// unknown). Accepted types: nil, []byte, string, io.ReadSeeker (replayed via
+// Seek), a func() (io.ReadCloser, error) factory (called once per attempt,
+// assumed already replayable), or any other io.Reader, which is buffered in
+// memory up to maxSize bytes.
+func newBodyGetter(body interface{}, maxSize int64) (func() (io.ReadCloser, error), int64, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, 0, nil
+	case func() (io.ReadCloser, error):
+		return b, -1, nil
+	case []byte:
+		return func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		}, int64(len(b)), nil
+	case string:
+		return func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader([]byte(b))), nil
+		}, int64(len(b)), nil
+	case io.ReadSeeker:
+		return func() (io.ReadCloser, error) {
+			if _, err := b.Seek(0, io.SeekStart); err != nil {
+				return nil, errors.Annotate(err, "failed to rewind request body")
+			}
+			return ioutil.NopCloser(b), nil
+		}, -1, nil
+	case io.Reader:
+		data, err := ioutil.ReadAll(io.LimitReader(b, maxSize+1))
+		if err != nil {
+			return nil, 0, errors.Annotate(err, "failed to buffer request body")
+		}
+		if int64(len(data)) > maxSize {
+			return nil, 0, errors.Reason(
+				"request body exceeds %d bytes and is not seekable; "+
+					"pass an io.ReadSeeker or a func() (io.ReadCloser, error) instead",
+				maxSize)
+		}
+		return func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		}, int64(len(data)), nil
+	default:
+		return nil, 0, errors.Reason("unsupported request body type %T", body)
+	}
+}
+
+// NewRequest builds an *http.Request for method and uri whose body can be
+// safely replayed on retry. `body` may be nil, []byte, string,
+// io.ReadSeeker, a func() (io.ReadCloser, error) factory, or any other
+// io.Reader (buffered in memory up to params.MaxBodySize, or
+// DefaultMaxBodySize if unset or non-positive); anything else, or an
+// unseekable io.Reader over the size cap, is a non-nil error rather than a
+// silently non-retriable request.
+func NewRequest(ctx context.Context, method, uri string, body interface{}, params *Params) (*http.Request, error) {
+	if params == nil {
+		params = NewParams()
+	}
+	maxSize := params.MaxBodySize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBodySize
+	}
+	getBody, length, err := newBodyGetter(body, maxSize)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid request body")
+	}
+	var rc io.ReadCloser
+	if getBody != nil {
+		if rc, err = getBody(); err != nil {
+			return nil, errors.Annotate(err, "failed to prepare request body")
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, uri, rc)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to create HTTP request")
+	}
+	if getBody != nil {
+		req.GetBody = getBody
+	}
+	if length >= 0 {
+		req.ContentLength = length
+	}
+	return req, nil
+}
+
+// Do sends req, retrying transient failures per params (NewParams() if nil).
+// Before each attempt after the first it replays req.Body from req.GetBody
+// (set by NewRequest for replayable bodies; a request with a body but no
+// GetBody is treated as non-retriable), and it drains and closes the
+// previous response's body so its connection is freed back to the pool.
+func Do(ctx context.Context, req *http.Request, params *Params) (*http.Response, error) {
+	if params == nil {
+		params = NewParams()
+	}
+	client := http.DefaultClient
+	if c := GetClient(ctx); c != nil {
+		client = c
+	}
+	var resp *http.Response
+	err := Retry(ctx, params, func(i int) error {
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body) //nolint:errcheck // best-effort drain before retry
+			resp.Body.Close()
+			resp = nil
+		}
+		r := req
+		if i > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return errors.Reason(
+					"request body is not replayable; build it with NewRequest to retry")
+			}
+			rc, err := req.GetBody()
+			if err != nil {
+				return errors.Annotate(err, "failed to rewind request body")
+			}
+			r = req.Clone(ctx)
+			r.Body = rc
+		}
+		report, err := gate(ctx, params, req.URL.String())
+		if err != nil {
+			if r != req {
+				r.Body.Close()
+			}
+			return err
+		}
+		getResp, getErr := client.Do(r)
+		resp = getResp
+		classifyErr := classifyAttempt(req.Method, req.URL.String(), getResp, getErr, params)
+		if report != nil {
+			report(classifyErr == nil)
+		}
+		return classifyErr
+	})
+	return resp, err
+}
+
+// Post sends a "POST" request with the given body (see NewRequest for
+// accepted body types), retrying transient failures per params.
+func Post(ctx context.Context, uri string, query url.Values, body interface{}, params *Params) (*http.Response, error) {
+	return doMethod(ctx, http.MethodPost, uri, query, body, params)
+}
+
+// Put sends a "PUT" request with the given body (see NewRequest for accepted
+// body types), retrying transient failures per params.
+func Put(ctx context.Context, uri string, query url.Values, body interface{}, params *Params) (*http.Response, error) {
+	return doMethod(ctx, http.MethodPut, uri, query, body, params)
+}
+
+// Patch sends a "PATCH" request with the given body (see NewRequest for
+// accepted body types), retrying transient failures per params.
+func Patch(ctx context.Context, uri string, query url.Values, body interface{}, params *Params) (*http.Response, error) {
+	return doMethod(ctx, http.MethodPatch, uri, query, body, params)
+}
+
+// Delete sends a "DELETE" request, optionally with a body (see NewRequest
+// for accepted body types), retrying transient failures per params.
+func Delete(ctx context.Context, uri string, query url.Values, body interface{}, params *Params) (*http.Response, error) {
+	return doMethod(ctx, http.MethodDelete, uri, query, body, params)
+}
+
+// doMethod builds a request for method via NewRequest and sends it with Do;
+// it backs Post, Put, Patch and Delete.
+func doMethod(ctx context.Context, method, uri string, query url.Values, body interface{}, params *Params) (*http.Response, error) {
+	if params == nil {
+		params = NewParams()
+	}
+	req, err := NewRequest(ctx, method, uri, body, params)
+	if err != nil {
+		return nil, err
+	}
+	if query != nil {
+		req.URL.RawQuery = query.Encode()
+	}
+	return Do(ctx, req, params)
+}
+
 // FetchJSON fetches a JSON blob from uri using GetRetry and unpacks it into
 // result.
 func FetchJSON(ctx context.Context, uri string, result interface{}, query url.Values, params *Params) error {
@@ -213,3 +908,108 @@ func FetchJSON(ctx context.Context, uri string, result interface{}, query url.Va
 	}
 	return nil
 }
+
+// ErrPartialStream wraps an error from a streaming decode callback (see
+// FetchJSONStream and FetchNDJSON) that occurred after the callback had
+// already consumed at least one byte of the response body. Resuming such a
+// stream would re-deliver records already seen by the caller, so it is
+// never retried, even where IsRetriable accepts the error it wraps.
+type ErrPartialStream struct {
+	Err error
+}
+
+// Error implements error.
+func (e *ErrPartialStream) Error() string {
+	return fmt.Sprintf("stream interrupted after partial delivery: %s", e.Err)
+}
+
+// Unwrap supports errors.As/errors.Is against the wrapped error.
+func (e *ErrPartialStream) Unwrap() error {
+	return e.Err
+}
+
+// countingReader wraps an io.Reader and records whether any bytes have been
+// read from it yet.
+type countingReader struct {
+	r    io.Reader
+	read bool
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.read = true
+	}
+	return n, err
+}
+
+// FetchJSONStream fetches uri via Get, gated by params.HostLimiter and
+// params.Breaker like GetRetry, and hands decode a json.Decoder reading
+// directly off the response body, instead of buffering it whole as
+// FetchJSON does. decode is expected to drive the decoder itself (e.g. via
+// repeated Decode calls) and return nil once it has consumed as much of the
+// stream as it wants.
+//
+// The fetch itself is retried as usual on a retriable failure, but only for
+// as long as decode has not yet read any bytes of the body; once it has,
+// a failure is wrapped in ErrPartialStream and returned without a retry,
+// since replaying the request would re-deliver records decode already saw.
+func FetchJSONStream(ctx context.Context, uri string, query url.Values, params *Params, decode func(decoder *json.Decoder) error) error {
+	if params == nil {
+		params = NewParams()
+	}
+	var partial *ErrPartialStream
+	retryCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	err := Retry(retryCtx, params, func(i int) error {
+		report, err := gate(ctx, params, uri)
+		if err != nil {
+			return err
+		}
+		resp, err := Get(ctx, uri, query, params)
+		if report != nil {
+			report(err == nil)
+		}
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		cr := &countingReader{r: resp.Body}
+		if err := decode(json.NewDecoder(cr)); err != nil {
+			if cr.read {
+				// Canceling retryCtx stops Retry even if the wrapped error is retriable.
+				partial = &ErrPartialStream{Err: err}
+				stop()
+				return partial
+			}
+			return err
+		}
+		return nil
+	})
+	if partial != nil {
+		return partial
+	}
+	return err
+}
+
+// FetchNDJSON fetches uri via FetchJSONStream and invokes fn once per
+// decoded value of a newline-delimited JSON (NDJSON) feed, without
+// buffering the whole response in memory. It stops and returns nil at the
+// end of the stream, or the first error from decoding or from fn (see
+// FetchJSONStream for retry behavior on mid-stream failures).
+func FetchNDJSON[T any](ctx context.Context, uri string, query url.Values, params *Params, fn func(T) error) error {
+	return FetchJSONStream(ctx, uri, query, params, func(dec *json.Decoder) error {
+		for {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return errors.Annotate(err, "failed to decode NDJSON record")
+			}
+			if err := fn(v); err != nil {
+				return errors.Annotate(err, "NDJSON callback failed")
+			}
+		}
+	})
+}
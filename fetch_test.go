@@ -15,13 +15,21 @@
 package fetch
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/stockparfait/errors"
+
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -39,7 +47,7 @@ func TestFetch(t *testing.T) {
 		ctx := UseClient(context.Background(), server.Client())
 
 		Convey("Get handles a response", func() {
-			r, err := Get(ctx, server.URL(), requestQuery)
+			r, err := Get(ctx, server.URL(), requestQuery, nil)
 			So(err, ShouldBeNil)
 			var respBody = make([]byte, 200)
 			n, err := r.Body.Read(respBody)
@@ -70,6 +78,171 @@ func TestFetch(t *testing.T) {
 			So(r.StatusCode, ShouldEqual, http.StatusForbidden)
 		})
 
+		Convey("GetRetry honors Retry-After on 503", func() {
+			server.ResponseStatus = []int{http.StatusServiceUnavailable, http.StatusOK}
+			server.ResponseHeader = http.Header{"Retry-After": []string{"0"}}
+			start := time.Now()
+			r, err := GetRetry(ctx, server.URL(), requestQuery, testParams)
+			So(err, ShouldBeNil)
+			So(r.StatusCode, ShouldEqual, http.StatusOK)
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+		})
+
+		Convey("GetRetry retries on 429 Too Many Requests", func() {
+			server.ResponseStatus = []int{http.StatusTooManyRequests, http.StatusOK}
+			r, err := GetRetry(ctx, server.URL(), requestQuery, testParams)
+			So(err, ShouldBeNil)
+			So(r.StatusCode, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("DefaultRetryPolicy does not retry a cert error", func() {
+			certErr := x509.UnknownAuthorityError{}
+			retriable, err := DefaultRetryPolicy(nil, certErr)
+			So(retriable, ShouldBeFalse)
+			So(err, ShouldResemble, certErr)
+		})
+
+		Convey("DefaultRetryPolicy retries a connection reset", func() {
+			retriable, err := DefaultRetryPolicy(nil, &url.Error{Op: "Get", URL: "http://x", Err: syscall.ECONNRESET})
+			So(retriable, ShouldBeTrue)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Retry applies jitter within bounds", func() {
+			wait := 10 * time.Millisecond
+			for i := 0; i < 20; i++ {
+				d := jitter(wait, 0.5)
+				So(d, ShouldBeGreaterThanOrEqualTo, wait/2)
+				So(d, ShouldBeLessThanOrEqualTo, wait+wait/2)
+			}
+		})
+
+		Convey("Post sends a body", func() {
+			r, err := Post(ctx, server.URL(), nil, []byte(`{"k":"v"}`), nil)
+			So(err, ShouldBeNil)
+			So(r.StatusCode, ShouldEqual, http.StatusOK)
+			So(server.RequestBody, ShouldEqual, `{"k":"v"}`)
+		})
+
+		Convey("Put replays the body on retry", func() {
+			server.ResponseStatus = []int{http.StatusInternalServerError, http.StatusOK}
+			r, err := Put(ctx, server.URL(), nil, strings.NewReader("payload"), testParams)
+			So(err, ShouldBeNil)
+			So(r.StatusCode, ShouldEqual, http.StatusOK)
+			So(server.RequestCount, ShouldEqual, 2)
+			So(server.RequestBody, ShouldEqual, "payload")
+		})
+
+		Convey("Put replays a buffered unseekable body on retry", func() {
+			server.ResponseStatus = []int{http.StatusInternalServerError, http.StatusOK}
+			r, err := Put(ctx, server.URL(), nil, bytes.NewBufferString("payload"), testParams)
+			So(err, ShouldBeNil)
+			So(r.StatusCode, ShouldEqual, http.StatusOK)
+			So(server.RequestCount, ShouldEqual, 2)
+			So(server.RequestBody, ShouldEqual, "payload")
+		})
+
+		Convey("NewRequest rejects an oversized unseekable body", func() {
+			big := bytes.NewBuffer(make([]byte, 10))
+			p := NewParams().WithMaxBodySize(5)
+			_, err := NewRequest(ctx, http.MethodPost, server.URL(), big, p)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Delete sends without a body", func() {
+			r, err := Delete(ctx, server.URL(), nil, nil, nil)
+			So(err, ShouldBeNil)
+			So(r.StatusCode, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Limiter paces attempts", func() {
+			limiter := NewRateLimiter(1000, 1)
+			p := testParams.WithLimiter(limiter)
+			r, err := GetRetry(ctx, server.URL(), requestQuery, p)
+			So(err, ShouldBeNil)
+			So(r.StatusCode, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("HostLimiter paces per-host, and GetRetry aborts on canceled context", func() {
+			var calls int
+			hl := NewHostLimiter(func() Limiter {
+				calls++
+				return NewRateLimiter(1000, 1)
+			})
+			p := testParams.WithHostLimiter(hl)
+			_, err := GetRetry(ctx, server.URL(), requestQuery, p)
+			So(err, ShouldBeNil)
+			So(calls, ShouldEqual, 1)
+
+			cctx, cancel := context.WithCancel(ctx)
+			cancel()
+			_, err = GetRetry(cctx, server.URL(), requestQuery, p)
+			So(err, ShouldNotBeNil)
+			So(calls, ShouldEqual, 1) // the canceled call stops before reaching the limiter
+		})
+
+		Convey("MaxInFlight caps concurrent Retry calls", func() {
+			p := testParams.WithMaxInFlight(1)
+			var inFlight, maxSeen int32
+			var mu sync.Mutex
+			run := func() error {
+				return Retry(ctx, p, func(i int) error {
+					mu.Lock()
+					inFlight++
+					if inFlight > maxSeen {
+						maxSeen = inFlight
+					}
+					mu.Unlock()
+					time.Sleep(5 * time.Millisecond)
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+					return nil
+				})
+			}
+			var wg sync.WaitGroup
+			errs := make([]error, 3)
+			for i := 0; i < 3; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					errs[i] = run()
+				}(i)
+			}
+			wg.Wait()
+			for _, e := range errs {
+				So(e, ShouldBeNil)
+			}
+			So(maxSeen, ShouldEqual, 1)
+		})
+
+		Convey("Breaker trips after consecutive failures and recovers", func() {
+			server.ResponseStatus = []int{http.StatusInternalServerError}
+			var opened, closed int
+			breaker := NewBreaker(2, time.Minute, 10*time.Millisecond)
+			breaker.OnOpen = func(string) { opened++ }
+			breaker.OnClose = func(string) { closed++ }
+			p := NewParams().MinWait(time.Millisecond).MaxWait(time.Millisecond).
+				Retries(0).WithBreaker(breaker)
+
+			_, err := GetRetry(ctx, server.URL(), requestQuery, p)
+			So(err, ShouldNotBeNil)
+			_, err = GetRetry(ctx, server.URL(), requestQuery, p)
+			So(err, ShouldNotBeNil)
+			So(opened, ShouldEqual, 1)
+
+			_, err = GetRetry(ctx, server.URL(), requestQuery, p)
+			var openErr *CircuitOpenError
+			So(errors.As(err, &openErr), ShouldBeTrue)
+
+			time.Sleep(20 * time.Millisecond)
+			server.ResponseStatus = []int{http.StatusOK}
+			r, err := GetRetry(ctx, server.URL(), requestQuery, p)
+			So(err, ShouldBeNil)
+			So(r.StatusCode, ShouldEqual, http.StatusOK)
+			So(closed, ShouldEqual, 1)
+		})
+
 		Convey("FetchJSON successfully decodes a struct", func() {
 			type FieldType struct {
 				Field string `json:"field"`
@@ -81,5 +254,55 @@ func TestFetch(t *testing.T) {
 			So(f.Field, ShouldEqual, "test value")
 		})
 
+		Convey("FetchJSONStream decodes without buffering the whole body", func() {
+			server.ResponseBody = []string{`{"n": 1}{"n": 2}{"n": 3}`}
+			var seen []int
+			err := FetchJSONStream(ctx, server.URL(), nil, nil, func(dec *json.Decoder) error {
+				for {
+					var v struct {
+						N int `json:"n"`
+					}
+					if err := dec.Decode(&v); err != nil {
+						if err == io.EOF {
+							return nil
+						}
+						return err
+					}
+					seen = append(seen, v.N)
+				}
+			})
+			So(err, ShouldBeNil)
+			So(seen, ShouldResemble, []int{1, 2, 3})
+		})
+
+		Convey("FetchNDJSON invokes fn per record", func() {
+			type Record struct {
+				N int `json:"n"`
+			}
+			server.ResponseBody = []string{"{\"n\": 1}\n{\"n\": 2}\n"}
+			var seen []int
+			err := FetchNDJSON(ctx, server.URL(), nil, nil, func(r Record) error {
+				seen = append(seen, r.N)
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(seen, ShouldResemble, []int{1, 2})
+		})
+
+		Convey("FetchJSONStream reports ErrPartialStream instead of retrying mid-stream", func() {
+			server.ResponseBody = []string{`{"n": 1}`}
+			boom := errors.Reason("boom")
+			err := FetchJSONStream(ctx, server.URL(), nil, nil, func(dec *json.Decoder) error {
+				var v struct {
+					N int `json:"n"`
+				}
+				So(dec.Decode(&v), ShouldBeNil)
+				return boom
+			})
+			var partial *ErrPartialStream
+			So(errors.As(err, &partial), ShouldBeTrue)
+			So(server.RequestCount, ShouldEqual, 1)
+		})
+
 	})
 }
@@ -16,6 +16,7 @@ package fetch
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -29,8 +30,11 @@ type ServerHandle struct {
 	ResponseBodyMap   map[string][]string // URL path -> response body sequence
 	ResponseStatus    []int               // default status codes sequence
 	ResponseBody      []string            // default response body sequnece
+	ResponseHeader    http.Header         // headers to set on every response
 	RequestPath       string              // path in the request URL
 	RequestQuery      url.Values          // query received by the server in the request
+	RequestBody       string              // body received in the last request
+	RequestCount      int                 // number of requests received so far
 	Server            *httptest.Server
 }
 
@@ -91,9 +95,17 @@ func NewTestServer() *ServerHandle {
 					h.ResponseBody = bodySeq
 				}
 			}
+			for k, vs := range h.ResponseHeader {
+				for _, v := range vs {
+					w.Header().Add(k, v)
+				}
+			}
 			w.WriteHeader(status)
 			h.RequestPath = r.URL.Path
 			h.RequestQuery = r.URL.Query()
+			reqBody, _ := ioutil.ReadAll(r.Body)
+			h.RequestBody = string(reqBody)
+			h.RequestCount++
 			fmt.Fprint(w, body)
 		}))
 	return &h